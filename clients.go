@@ -0,0 +1,56 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import "github.com/hooklift/oauth2/types"
+
+// grantsRequiringClientAuth are the grant types that public clients, which
+// cannot keep a secret, are never allowed to use. See
+// http://tools.ietf.org/html/rfc6749#section-2.1 and
+// http://tools.ietf.org/html/rfc8252#section-8.4.
+var grantsRequiringClientAuth = map[string]bool{
+	"client_credentials": true,
+	"password":           true,
+}
+
+// AuthenticateClient decides what the token endpoint requires of client, for
+// the given grantType: confidential clients must always authenticate with
+// their client credentials; public clients may omit client authentication,
+// and are barred from the client_credentials and password grants entirely,
+// since those grants require a client secret to make any sense. PKCE is
+// only required of a public client on the authorization_code grant, the
+// only one where a code_verifier exists to check; a public client refreshing
+// a token or exchanging one has nothing else to prove its identity with and
+// is let through on client_id alone, same as the authorization server
+// already trusted it with when it first issued that token.
+func AuthenticateClient(client types.Client, grantType string, authenticated, hasCodeVerifier bool) *types.AuthzError {
+	if client.Type == types.Public {
+		if grantsRequiringClientAuth[grantType] {
+			return &types.AuthzError{
+				Code:        "unauthorized_client",
+				Description: "Public clients cannot use the " + grantType + " grant.",
+			}
+		}
+
+		if grantType == "authorization_code" && !authenticated && !hasCodeVerifier {
+			return &types.AuthzError{
+				Code:        "invalid_request",
+				Description: "Public clients must present a PKCE code_verifier.",
+			}
+		}
+
+		return nil
+	}
+
+	// Confidential clients, the default, must always authenticate.
+	if !authenticated {
+		return &types.AuthzError{
+			Code:        "unauthorized_client",
+			Description: "Confidential clients must authenticate with their client credentials.",
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import (
+	"testing"
+
+	"github.com/hooklift/oauth2/types"
+)
+
+// TestAuthenticateClient tests that confidential clients must always
+// authenticate while public clients may rely on PKCE instead, per
+// http://tools.ietf.org/html/rfc6749#section-2.1 and
+// http://tools.ietf.org/html/rfc8252#section-8.4.
+func TestAuthenticateClient(t *testing.T) {
+	confidential := types.Client{ID: "confidential", Type: types.Confidential}
+	public := types.Client{ID: "public", Type: types.Public}
+
+	tests := []struct {
+		name            string
+		client          types.Client
+		grantType       string
+		authenticated   bool
+		hasCodeVerifier bool
+		wantErr         bool
+	}{
+		{name: "confidential authenticated", client: confidential, grantType: "authorization_code", authenticated: true},
+		{name: "confidential unauthenticated", client: confidential, grantType: "authorization_code", wantErr: true},
+		{name: "public with PKCE", client: public, grantType: "authorization_code", hasCodeVerifier: true},
+		{name: "public authenticated without PKCE", client: public, grantType: "authorization_code", authenticated: true},
+		{name: "public without auth or PKCE", client: public, grantType: "authorization_code", wantErr: true},
+		{name: "public client_credentials barred", client: public, grantType: "client_credentials", authenticated: true, wantErr: true},
+		{name: "public password barred", client: public, grantType: "password", authenticated: true, wantErr: true},
+		{name: "public refresh_token without PKCE", client: public, grantType: "refresh_token"},
+		{name: "public token-exchange without PKCE", client: public, grantType: TokenExchangeGrantType},
+	}
+
+	for _, tt := range tests {
+		err := AuthenticateClient(tt.client, tt.grantType, tt.authenticated, tt.hasCodeVerifier)
+		if tt.wantErr {
+			assert(t, err != nil, "%s: expected an error", tt.name)
+			continue
+		}
+		assert(t, err == nil, "%s: unexpected error: %v", tt.name, err)
+	}
+}
@@ -0,0 +1,95 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import (
+	"crypto"
+	"time"
+
+	"github.com/hooklift/oauth2/providers"
+)
+
+// config holds the settings the handlers in this package need at runtime.
+// Applications construct one with New and pass it to every handler.
+type config struct {
+	provider providers.Provider
+	// tokenExpiration is how long newly minted access tokens live.
+	tokenExpiration time.Duration
+	// issuer is this server's issuer identifier, used in id_tokens and in
+	// the OpenID Connect discovery document.
+	issuer string
+	// realm identifies the protected resource in WWW-Authenticate
+	// challenges. See http://tools.ietf.org/html/rfc6750#section-3
+	realm string
+	// signingKey signs id_tokens. Either an *rsa.PrivateKey (RS256) or an
+	// *ecdsa.PrivateKey (ES256).
+	signingKey crypto.Signer
+	// signingKeyID is the "kid" advertised in the JWKS document for
+	// signingKey.
+	signingKeyID string
+	// requirePKCEForPublicClients rejects authorization_code exchanges
+	// from public clients that did not send a code_challenge.
+	requirePKCEForPublicClients bool
+	// s256Only rejects the "plain" PKCE code_challenge_method, requiring
+	// S256 instead.
+	s256Only bool
+}
+
+// Option configures optional config settings in New. Applications that only
+// need the core grants can ignore it entirely; PKCE enforcement, OIDC, and a
+// custom realm are all opt-in through one of these.
+type Option func(*config)
+
+// WithIssuer sets the issuer identifier advertised in id_tokens and the
+// OpenID Connect discovery document.
+func WithIssuer(issuer string) Option {
+	return func(cfg *config) { cfg.issuer = issuer }
+}
+
+// WithRealm sets the realm identifying the protected resource in
+// WWW-Authenticate challenges. Defaults to "oauth2". See
+// http://tools.ietf.org/html/rfc6750#section-3
+func WithRealm(realm string) Option {
+	return func(cfg *config) { cfg.realm = realm }
+}
+
+// WithSigningKey configures the key used to sign id_tokens, and the "kid"
+// advertised for it in the JWKS document. key must be an *rsa.PrivateKey
+// (RS256) or an *ecdsa.PrivateKey (ES256).
+func WithSigningKey(key crypto.Signer, keyID string) Option {
+	return func(cfg *config) {
+		cfg.signingKey = key
+		cfg.signingKeyID = keyID
+	}
+}
+
+// WithPKCERequiredForPublicClients rejects authorization_code exchanges
+// from public clients that did not send a code_challenge, instead of
+// treating PKCE as optional for them.
+func WithPKCERequiredForPublicClients(required bool) Option {
+	return func(cfg *config) { cfg.requirePKCEForPublicClients = required }
+}
+
+// WithS256Only rejects the "plain" PKCE code_challenge_method, requiring
+// S256 instead.
+func WithS256Only(s256Only bool) Option {
+	return func(cfg *config) { cfg.s256Only = s256Only }
+}
+
+// New creates the configuration shared by every handler in this package,
+// applying any Options given.
+func New(provider providers.Provider, tokenExpiration time.Duration, opts ...Option) *config {
+	cfg := &config{
+		provider:        provider,
+		tokenExpiration: tokenExpiration,
+		realm:           "oauth2",
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
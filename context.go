@@ -0,0 +1,26 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import (
+	"context"
+
+	"github.com/hooklift/oauth2/types"
+)
+
+type contextKey int
+
+const tokenContextKey contextKey = 0
+
+func withToken(ctx context.Context, token types.Token) context.Context {
+	return context.WithValue(ctx, tokenContextKey, token)
+}
+
+// TokenFromContext returns the access token that ResourceProtect validated
+// for the current request, if any.
+func TokenFromContext(ctx context.Context) (types.Token, bool) {
+	token, ok := ctx.Value(tokenContextKey).(types.Token)
+	return token, ok
+}
@@ -0,0 +1,77 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/hooklift/oauth2/providers/test"
+	"github.com/hooklift/oauth2/types"
+)
+
+// assert fails tb with msg, formatted with v, unless condition holds.
+func assert(tb testing.TB, condition bool, msg string, v ...interface{}) {
+	if !condition {
+		_, file, line, _ := runtime.Caller(1)
+		fmt.Printf("%s:%d: "+msg+"\n\n", append([]interface{}{filepath.Base(file), line}, v...)...)
+		tb.FailNow()
+	}
+}
+
+// ok fails tb if err is non-nil.
+func ok(tb testing.TB, err error) {
+	if err != nil {
+		_, file, line, _ := runtime.Caller(1)
+		fmt.Printf("%s:%d: unexpected error: %s\n\n", filepath.Base(file), line, err.Error())
+		tb.FailNow()
+	}
+}
+
+// equals fails tb unless exp and act are deeply equal.
+func equals(tb testing.TB, exp, act interface{}) {
+	if !reflect.DeepEqual(exp, act) {
+		_, file, line, _ := runtime.Caller(1)
+		fmt.Printf("%s:%d: exp: %#v\n\n\tgot: %#v\n\n", filepath.Base(file), line, exp, act)
+		tb.FailNow()
+	}
+}
+
+func mustParseURL(rawurl string) *url.URL {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// setupTest returns the configuration shared by every handler test in this
+// package, backed by the in-memory test provider.
+func setupTest() *config {
+	return New(test.NewProvider(false), 600*time.Second, WithIssuer("https://example.com"))
+}
+
+// getTestAuthzCode registers a fresh authorization grant code for
+// "testclient" and returns the configuration and code value to exchange it
+// with.
+func getTestAuthzCode(t *testing.T) (*config, string) {
+	cfg := setupTest()
+
+	provider := cfg.provider.(*test.Provider)
+	err := provider.AddGrantCode(types.GrantCode{
+		Value:       "test_authz_code",
+		ClientID:    "testclient",
+		RedirectURL: mustParseURL("https://example.com/oauth2/callback"),
+		Scope:       []types.Scope{{ID: "identity"}},
+	})
+	ok(t, err)
+
+	return cfg, "test_authz_code"
+}
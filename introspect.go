@@ -0,0 +1,105 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/hooklift/oauth2/types"
+)
+
+// introspectionResponse is the RFC 7662 token introspection response.
+// http://tools.ietf.org/html/rfc7662#section-2.2
+type introspectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+}
+
+// Introspect implements the OAuth 2.0 Token Introspection endpoint, per
+// http://tools.ietf.org/html/rfc7662. It authenticates the caller as a
+// registered client with HTTP Basic client credentials, looks up the token
+// with the configured provider, and reports whether it is still active.
+// Unlike IssueToken, it never accepts a bare client_id, so public clients,
+// which have no secret, cannot call it.
+//
+// To avoid leaking token state to clients other than the one it was issued
+// to, an unknown, revoked or expired token is reported simply as
+// {"active": false}.
+func Introspect(w http.ResponseWriter, r *http.Request, cfg *config) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	client, authzErr := requireClientAuth(r, cfg)
+	if authzErr != nil {
+		writeJSON(w, http.StatusBadRequest, authzErr)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "invalid_request",
+			Description: "Unable to parse request: " + err.Error(),
+		})
+		return
+	}
+
+	tokenValue := r.FormValue("token")
+	if tokenValue == "" {
+		writeJSON(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "invalid_request",
+			Description: "token parameter is required.",
+		})
+		return
+	}
+
+	token, err := cfg.provider.LookupToken(tokenValue)
+	if err != nil || token.ClientID != client.ID || token.IsRevoked || token.IsExpired {
+		writeJSON(w, http.StatusOK, introspectionResponse{Active: false})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, introspectionResponse{
+		Active:    true,
+		Scope:     joinScopes(token.Scope),
+		ClientID:  token.ClientID,
+		Exp:       token.ExpiresAt,
+		Iat:       token.IssuedAt,
+		TokenType: "bearer",
+	})
+}
+
+// writeJSON writes v as the JSON response body. An *types.AuthzError is
+// marshaled through its JSON method, which composes the token-endpoint body
+// form described by http://tools.ietf.org/html/rfc6749#section-5.2, as
+// opposed to its Error method, which composes the WWW-Authenticate
+// challenge form used by ResourceProtect.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if authzErr, ok := v.(*types.AuthzError); ok {
+		body, err := authzErr.JSON()
+		if err == nil {
+			w.Write(body)
+			return
+		}
+	}
+
+	json.NewEncoder(w).Encode(v)
+}
+
+func joinScopes(scopes []types.Scope) string {
+	ids := make([]string, len(scopes))
+	for i, scope := range scopes {
+		ids[i] = scope.ID
+	}
+	return strings.Join(ids, " ")
+}
@@ -0,0 +1,91 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/hooklift/oauth2/providers/test"
+	"github.com/hooklift/oauth2/types"
+)
+
+func introspectRequest(t *testing.T, token string) *http.Request {
+	queryStr := url.Values{"token": {token}}
+	buffer := bytes.NewBufferString(queryStr.Encode())
+	req, err := http.NewRequest("POST", "https://example.com/oauth2/introspect", buffer)
+	ok(t, err)
+	req.Header.Set("Content-type", "application/x-www-form-urlencoded")
+	return req
+}
+
+// TestIntrospectPublicClientCannotAuthenticateWithEmptySecret tests that a
+// public client, which has no secret, cannot authenticate to the
+// introspection endpoint by sending its client_id with a blank password.
+func TestIntrospectPublicClientCannotAuthenticateWithEmptySecret(t *testing.T) {
+	cfg := setupTest()
+
+	req := introspectRequest(t, "whatever")
+	req.SetBasicAuth("test_public", "")
+
+	w := httptest.NewRecorder()
+	Introspect(w, req, cfg)
+
+	equals(t, http.StatusBadRequest, w.Code)
+
+	authzErr := types.AuthzError{}
+	err := json.Unmarshal(w.Body.Bytes(), &authzErr)
+	ok(t, err)
+	equals(t, "unauthorized_client", authzErr.Code)
+}
+
+// TestIntrospectActiveToken tests the happy path, including that exp/iat are
+// reported, per http://tools.ietf.org/html/rfc7662#section-2.2
+func TestIntrospectActiveToken(t *testing.T) {
+	cfg := setupTest()
+	provider := cfg.provider.(*test.Provider)
+
+	issued, err := provider.GenToken(types.Grant{
+		ClientID: "testclient",
+		Scopes:   types.Scopes{{ID: "identity"}},
+	}, types.Client{ID: "testclient"}, false, cfg.tokenExpiration, nil)
+	ok(t, err)
+
+	req := introspectRequest(t, issued.Value)
+	req.SetBasicAuth("testclient", "testclient")
+
+	w := httptest.NewRecorder()
+	Introspect(w, req, cfg)
+
+	resp := introspectionResponse{}
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	ok(t, err)
+
+	assert(t, resp.Active, "expected the token to be reported as active")
+	equals(t, "identity", resp.Scope)
+	assert(t, resp.Exp != 0, "expected exp to be set")
+	assert(t, resp.Iat != 0, "expected iat to be set")
+}
+
+// TestIntrospectUnknownToken tests that an unknown token is
+// reported simply as {"active": false}.
+func TestIntrospectUnknownToken(t *testing.T) {
+	cfg := setupTest()
+
+	req := introspectRequest(t, "does-not-exist")
+	req.SetBasicAuth("testclient", "testclient")
+
+	w := httptest.NewRecorder()
+	Introspect(w, req, cfg)
+
+	resp := introspectionResponse{}
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	ok(t, err)
+	equals(t, false, resp.Active)
+}
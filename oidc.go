@@ -0,0 +1,233 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/hooklift/oauth2/types"
+)
+
+// OpenIDScope is the scope value that triggers id_token issuance, as defined
+// by http://openid.net/specs/openid-connect-core-1_0.html#ScopeClaims
+const OpenIDScope = "openid"
+
+// IDTokenClaims are the claims carried by an OpenID Connect id_token. See
+// http://openid.net/specs/openid-connect-core-1_0.html#IDToken
+type IDTokenClaims struct {
+	Issuer   string `json:"iss"`
+	Subject  string `json:"sub"`
+	Audience string `json:"aud"`
+	Expiry   int64  `json:"exp"`
+	IssuedAt int64  `json:"iat"`
+	AuthTime int64  `json:"auth_time"`
+	Nonce    string `json:"nonce,omitempty"`
+	// Extra carries additional claims about Subject returned by a
+	// provider's UserInfo hook, merged into the id_token alongside the
+	// standard claims above.
+	Extra map[string]interface{} `json:"-"`
+}
+
+// marshalIDTokenClaims marshals claims, merging Extra into the same JSON
+// object as the standard claims rather than nesting it under its own key.
+func marshalIDTokenClaims(claims IDTokenClaims) ([]byte, error) {
+	if len(claims.Extra) == 0 {
+		return json.Marshal(claims)
+	}
+
+	std, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]interface{}, len(claims.Extra)+6)
+	if err := json.Unmarshal(std, &merged); err != nil {
+		return nil, err
+	}
+	for k, v := range claims.Extra {
+		merged[k] = v
+	}
+
+	return json.Marshal(merged)
+}
+
+// signingAlg returns the JWS alg header for key, which must be an
+// *rsa.PrivateKey (RS256) or an *ecdsa.PrivateKey (ES256).
+func signingAlg(key crypto.Signer) (string, error) {
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		return "RS256", nil
+	case *ecdsa.PrivateKey:
+		return "ES256", nil
+	default:
+		return "", fmt.Errorf("unsupported id_token signing key type %T", key)
+	}
+}
+
+// NewIDToken mints a signed id_token JWT from claims, using key as the
+// issuer's signing key (either RSA or ECDSA) and keyID as the "kid"
+// advertised in the JWKS document so relying parties can pick the right
+// key to verify it with.
+func NewIDToken(key crypto.Signer, keyID string, claims IDTokenClaims) (string, error) {
+	alg, err := signingAlg(key)
+	if err != nil {
+		return "", err
+	}
+
+	header, err := json.Marshal(map[string]string{
+		"alg": alg,
+		"typ": "JWT",
+		"kid": keyID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	body, err := marshalIDTokenClaims(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		sig, err = signECDSA(k, digest[:])
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// signECDSA signs digest with key and encodes the result as the
+// fixed-width, big-endian R||S pair required by
+// http://tools.ietf.org/html/rfc7518#section-3.4, rather than the ASN.1
+// DER form ecdsa.Sign's return values are normally packed into.
+func signECDSA(key *ecdsa.PrivateKey, digest []byte) ([]byte, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	size := (key.Curve.Params().BitSize + 7) / 8
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
+	return sig, nil
+}
+
+// openIDConfiguration is the OpenID Provider metadata document served at
+// /.well-known/openid-configuration. See
+// http://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata
+type openIDConfiguration struct {
+	Issuer                           string   `json:"issuer"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint"`
+	TokenEndpoint                    string   `json:"token_endpoint"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// DiscoveryHandler serves the OpenID Provider metadata document advertising
+// this server's endpoints and signing algorithm.
+func DiscoveryHandler(cfg *config) http.HandlerFunc {
+	alg, _ := signingAlg(cfg.signingKey)
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, openIDConfiguration{
+			Issuer:                           cfg.issuer,
+			AuthorizationEndpoint:            cfg.issuer + "/oauth2/authorize",
+			TokenEndpoint:                    cfg.issuer + "/oauth2/tokens",
+			JWKSURI:                          cfg.issuer + "/oauth2/jwks",
+			ResponseTypesSupported:           []string{"code"},
+			SubjectTypesSupported:            []string{"public"},
+			IDTokenSigningAlgValuesSupported: []string{alg},
+		})
+	}
+}
+
+// jwk is a JSON Web Key as defined by http://tools.ietf.org/html/rfc7517
+type jwk struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSHandler serves the JSON Web Key Set used by relying parties to verify
+// id_tokens minted with NewIDToken.
+func JWKSHandler(cfg *config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, err := publicJWK(cfg.signingKey, cfg.signingKeyID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, &types.AuthzError{
+				Code:        "server_error",
+				Description: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, struct {
+			Keys []jwk `json:"keys"`
+		}{Keys: []jwk{key}})
+	}
+}
+
+func publicJWK(key crypto.Signer, keyID string) (jwk, error) {
+	alg, err := signingAlg(key)
+	if err != nil {
+		return jwk{}, err
+	}
+
+	switch k := key.Public().(type) {
+	case *rsa.PublicKey:
+		return jwk{
+			Kty: "RSA",
+			Use: "sig",
+			Kid: keyID,
+			Alg: alg,
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		x := make([]byte, size)
+		y := make([]byte, size)
+		k.X.FillBytes(x)
+		k.Y.FillBytes(y)
+		return jwk{
+			Kty: "EC",
+			Use: "sig",
+			Kid: keyID,
+			Alg: alg,
+			Crv: k.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(x),
+			Y:   base64.RawURLEncoding.EncodeToString(y),
+		}, nil
+	default:
+		return jwk{}, fmt.Errorf("unsupported id_token signing key type %T", key)
+	}
+}
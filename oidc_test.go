@@ -0,0 +1,119 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hooklift/oauth2/providers/test"
+	"github.com/hooklift/oauth2/types"
+)
+
+func decodeJWTClaims(t *testing.T, idToken string) IDTokenClaims {
+	claims := IDTokenClaims{}
+	ok(t, json.Unmarshal(decodeJWTPayload(t, idToken), &claims))
+	return claims
+}
+
+func decodeJWTPayload(t *testing.T, idToken string) []byte {
+	parts := strings.Split(idToken, ".")
+	assert(t, len(parts) == 3, "expected a 3-part JWT, got %d parts", len(parts))
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	ok(t, err)
+	return raw
+}
+
+// TestNewIDTokenRSAAndECDSA tests that NewIDToken can sign with either an
+// RSA or an ECDSA key, per the "RSA/ECDSA signing key" requirement of
+// http://openid.net/specs/openid-connect-core-1_0.html#IDToken
+func TestNewIDTokenRSAAndECDSA(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	ok(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ok(t, err)
+
+	claims := IDTokenClaims{Issuer: "https://example.com", Subject: "testclient", Nonce: "abc123"}
+
+	rsaToken, err := NewIDToken(rsaKey, "rsa-key", claims)
+	ok(t, err)
+	equals(t, claims.Nonce, decodeJWTClaims(t, rsaToken).Nonce)
+
+	ecToken, err := NewIDToken(ecKey, "ec-key", claims)
+	ok(t, err)
+	equals(t, claims.Nonce, decodeJWTClaims(t, ecToken).Nonce)
+}
+
+// TestNewIDTokenMergesExtraClaims tests that Extra claims are merged into
+// the id_token's top-level JSON object rather than nested under their own
+// key.
+func TestNewIDTokenMergesExtraClaims(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	ok(t, err)
+
+	claims := IDTokenClaims{
+		Issuer:  "https://example.com",
+		Subject: "testclient",
+		Extra:   map[string]interface{}{"name": "Test Client"},
+	}
+
+	idToken, err := NewIDToken(rsaKey, "rsa-key", claims)
+	ok(t, err)
+
+	rawClaims := map[string]interface{}{}
+	ok(t, json.Unmarshal(decodeJWTPayload(t, idToken), &rawClaims))
+	equals(t, "Test Client", rawClaims["name"])
+	equals(t, "https://example.com", rawClaims["iss"])
+}
+
+// TestIssueTokenMintsIDTokenForOpenIDScope tests that IssueToken mints an
+// id_token when the authorization grant code carries the openid scope, per
+// http://openid.net/specs/openid-connect-core-1_0.html#CodeIDToken
+func TestIssueTokenMintsIDTokenForOpenIDScope(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	ok(t, err)
+
+	cfg := New(test.NewProvider(false), 600*time.Second,
+		WithIssuer("https://example.com"), WithSigningKey(rsaKey, "test-key"))
+
+	provider := cfg.provider.(*test.Provider)
+	err = provider.AddGrantCode(types.GrantCode{
+		Value:       "openid_authz_code",
+		ClientID:    "testclient",
+		RedirectURL: mustParseURL("https://example.com/oauth2/callback"),
+		Scope:       []types.Scope{{ID: "openid"}, {ID: "identity"}},
+		Nonce:       "the-nonce",
+	})
+	ok(t, err)
+
+	req := AuthzGrantTokenRequestTest(t, "authorization_code", "openid_authz_code")
+	req.SetBasicAuth("testclient", "testclient")
+
+	w := httptest.NewRecorder()
+	IssueToken(w, req, cfg)
+	equals(t, http.StatusOK, w.Code)
+
+	token := types.Token{}
+	ok(t, json.Unmarshal(w.Body.Bytes(), &token))
+	assert(t, token.IDToken != "", "expected an id_token to be minted")
+	equals(t, "the-nonce", decodeJWTClaims(t, token.IDToken).Nonce)
+
+	// The provider's UserInfo hook must be consulted and its claims merged
+	// into the id_token, not just the standard iss/sub/aud/exp/iat/nonce.
+	rawClaims := map[string]interface{}{}
+	ok(t, json.Unmarshal(decodeJWTPayload(t, token.IDToken), &rawClaims))
+	equals(t, "testclient display name", rawClaims["name"])
+}
@@ -0,0 +1,64 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+
+	"github.com/hooklift/oauth2/types"
+)
+
+// PKCE code challenge methods, as defined by
+// http://tools.ietf.org/html/rfc7636#section-4.3
+const (
+	PKCEPlain = "plain"
+	PKCES256  = "S256"
+)
+
+// VerifyPKCE checks that codeVerifier, sent by the client when exchanging an
+// authorization code for a token, matches the code challenge that was
+// recorded on the grant code at authorization time. See
+// http://tools.ietf.org/html/rfc7636#section-4.6.
+//
+// s256Only rejects the "plain" method, forcing clients to use S256 code
+// challenges.
+func VerifyPKCE(codeVerifier, codeChallenge, codeChallengeMethod string, s256Only bool) *types.AuthzError {
+	switch codeChallengeMethod {
+	case "", PKCEPlain:
+		if s256Only {
+			return &types.AuthzError{
+				Code:        "invalid_request",
+				Description: "This server requires the S256 PKCE code challenge method.",
+			}
+		}
+
+		if subtle.ConstantTimeCompare([]byte(codeVerifier), []byte(codeChallenge)) != 1 {
+			return &types.AuthzError{
+				Code:        "invalid_grant",
+				Description: "code_verifier does not match code_challenge.",
+			}
+		}
+
+	case PKCES256:
+		sum := sha256.Sum256([]byte(codeVerifier))
+		computed := base64.RawURLEncoding.EncodeToString(sum[:])
+		if subtle.ConstantTimeCompare([]byte(computed), []byte(codeChallenge)) != 1 {
+			return &types.AuthzError{
+				Code:        "invalid_grant",
+				Description: "code_verifier does not match code_challenge.",
+			}
+		}
+
+	default:
+		return &types.AuthzError{
+			Code:        "invalid_request",
+			Description: "Unsupported code_challenge_method: " + codeChallengeMethod,
+		}
+	}
+
+	return nil
+}
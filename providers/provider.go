@@ -0,0 +1,61 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package providers defines the interface applications embedding oauth2
+// implement to supply persistence and business logic the library itself
+// does not know about.
+package providers
+
+import (
+	"time"
+
+	"github.com/hooklift/oauth2/types"
+)
+
+// Provider is implemented by applications to back the token and
+// authorization endpoints with their own storage and policy decisions.
+type Provider interface {
+	// LookupClient returns the registered client with the given id.
+	LookupClient(id string) (types.Client, error)
+
+	// LookupGrantCode returns the authorization grant code previously
+	// issued with value.
+	LookupGrantCode(value string) (types.GrantCode, error)
+
+	// UseGrantCode marks the grant code with value as used, so that it
+	// cannot be exchanged for a token a second time. See
+	// http://tools.ietf.org/html/rfc6749#section-4.1.2
+	UseGrantCode(value string) error
+
+	// Authenticate validates resource owner credentials for the password
+	// grant and returns the scopes granted to them.
+	Authenticate(username, password string) (types.Grant, error)
+
+	// ClientGrant returns the scopes granted to client for the
+	// client_credentials grant.
+	ClientGrant(client types.Client) (types.Grant, error)
+
+	// LookupToken returns the access or refresh token with the given value.
+	LookupToken(value string) (types.Token, error)
+
+	// GenToken mints and persists a new access token, and optionally a
+	// refresh token, for grant issued to client. audience, when non-empty,
+	// restricts the token to those audiences per
+	// http://tools.ietf.org/html/rfc8693 and must be persisted alongside
+	// the token so later LookupToken calls can recover it.
+	GenToken(grant types.Grant, client types.Client, withRefresh bool, expiresIn time.Duration, audience []string) (types.Token, error)
+
+	// RevokeToken revokes the access or refresh token with the given
+	// value, per http://tools.ietf.org/html/rfc7009
+	RevokeToken(value string) error
+
+	// CanExchange reports whether client is permitted to exchange a token
+	// for audience, per http://tools.ietf.org/html/rfc8693
+	CanExchange(clientID, audience string) bool
+
+	// UserInfo returns OpenID Connect claims about subject, used to enrich
+	// id_tokens minted by IssueToken. See
+	// http://openid.net/specs/openid-connect-core-1_0.html#UserInfo
+	UserInfo(subject string) (map[string]interface{}, error)
+}
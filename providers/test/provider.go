@@ -0,0 +1,160 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package test provides an in-memory providers.Provider implementation used
+// by this module's own tests.
+package test
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hooklift/oauth2/types"
+)
+
+// Provider is an in-memory providers.Provider for tests.
+type Provider struct {
+	allowResourceOwnerGrant bool
+	clients                 map[string]types.Client
+	grantCodes              map[string]types.GrantCode
+	tokens                  map[string]types.Token
+	seq                     int
+}
+
+// NewProvider returns a Provider seeded with two confidential test clients
+// and one public one. allowResourceOwnerGrant controls whether Authenticate succeeds,
+// mirroring applications that disable the password grant.
+func NewProvider(allowResourceOwnerGrant bool) *Provider {
+	return &Provider{
+		allowResourceOwnerGrant: allowResourceOwnerGrant,
+		clients: map[string]types.Client{
+			"testclient":  {ID: "testclient", Type: types.Confidential, Secret: "testclient"},
+			"boo":         {ID: "boo", Type: types.Confidential, Secret: "boo"},
+			"test_public": {ID: "test_public", Type: types.Public},
+		},
+		grantCodes: make(map[string]types.GrantCode),
+		tokens:     make(map[string]types.Token),
+	}
+}
+
+// AddGrantCode registers code for a subsequent authorization_code exchange.
+// Only meant to be used by tests.
+func (p *Provider) AddGrantCode(code types.GrantCode) error {
+	p.grantCodes[code.Value] = code
+	return nil
+}
+
+// SetToken stores token as-is, keyed by its Value. Only meant to be used by
+// tests that need precise control over token state, e.g. an expired or
+// revoked token.
+func (p *Provider) SetToken(token types.Token) {
+	p.tokens[token.Value] = token
+}
+
+// AddClient registers client. Only meant to be used by tests.
+func (p *Provider) AddClient(client types.Client) error {
+	p.clients[client.ID] = client
+	return nil
+}
+
+func (p *Provider) LookupClient(id string) (types.Client, error) {
+	client, ok := p.clients[id]
+	if !ok {
+		return types.Client{}, errors.New("client not found")
+	}
+	return client, nil
+}
+
+func (p *Provider) LookupGrantCode(value string) (types.GrantCode, error) {
+	code, ok := p.grantCodes[value]
+	if !ok {
+		return types.GrantCode{}, errors.New("grant code not found")
+	}
+	return code, nil
+}
+
+func (p *Provider) UseGrantCode(value string) error {
+	code, ok := p.grantCodes[value]
+	if !ok {
+		return errors.New("grant code not found")
+	}
+	code.IsUsed = true
+	p.grantCodes[value] = code
+	return nil
+}
+
+func (p *Provider) Authenticate(username, password string) (types.Grant, error) {
+	if !p.allowResourceOwnerGrant || username != "test_user" || password != "test_password" {
+		return types.Grant{}, errors.New("invalid resource owner credentials")
+	}
+	return types.Grant{Scopes: types.Scopes{{ID: "identity"}}}, nil
+}
+
+func (p *Provider) ClientGrant(client types.Client) (types.Grant, error) {
+	return types.Grant{ClientID: client.ID, Scopes: types.Scopes{{ID: "identity"}}}, nil
+}
+
+func (p *Provider) LookupToken(value string) (types.Token, error) {
+	token, ok := p.tokens[value]
+	if !ok {
+		return types.Token{}, errors.New("token not found")
+	}
+	return token, nil
+}
+
+func (p *Provider) GenToken(grant types.Grant, client types.Client, withRefresh bool, expiresIn time.Duration, audience []string) (types.Token, error) {
+	p.seq++
+	now := time.Now()
+
+	token := types.Token{
+		ClientID:  client.ID,
+		Value:     fmt.Sprintf("access-token-%d", p.seq),
+		Type:      "bearer",
+		ExpiresIn: strconv.Itoa(int(expiresIn.Seconds())),
+		Scope:     grant.Scopes,
+		Audience:  audience,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(expiresIn).Unix(),
+	}
+
+	if withRefresh {
+		p.seq++
+		token.RefreshToken = fmt.Sprintf("refresh-token-%d", p.seq)
+	}
+
+	p.tokens[token.Value] = token
+	if token.RefreshToken != "" {
+		p.tokens[token.RefreshToken] = token
+	}
+
+	return token, nil
+}
+
+func (p *Provider) RevokeToken(value string) error {
+	token, ok := p.tokens[value]
+	if !ok {
+		return nil
+	}
+
+	token.IsRevoked = true
+	p.tokens[value] = token
+
+	if token.RefreshToken != "" && token.RefreshToken != value {
+		refreshed := p.tokens[token.RefreshToken]
+		refreshed.IsRevoked = true
+		p.tokens[token.RefreshToken] = refreshed
+	}
+
+	return nil
+}
+
+func (p *Provider) CanExchange(clientID, audience string) bool {
+	return true
+}
+
+func (p *Provider) UserInfo(subject string) (map[string]interface{}, error) {
+	return map[string]interface{}{"name": subject + " display name"}, nil
+}
@@ -0,0 +1,86 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hooklift/oauth2/types"
+)
+
+// ResourceProtect wraps next with bearer token validation, per
+// http://tools.ietf.org/html/rfc6750. Requests without a valid access token
+// granting at least requiredScopes are rejected with a WWW-Authenticate
+// challenge instead of reaching next.
+func ResourceProtect(next http.Handler, cfg *config, requiredScopes ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, authzErr := bearerToken(r, cfg, requiredScopes)
+		if authzErr != nil {
+			challenge(w, authzErr)
+			return
+		}
+
+		r = r.WithContext(withToken(r.Context(), token))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bearerToken extracts and validates the bearer token from r per
+// http://tools.ietf.org/html/rfc6750#section-2.1, ensuring it grants every
+// scope in requiredScopes.
+func bearerToken(r *http.Request, cfg *config, requiredScopes []string) (types.Token, *types.AuthzError) {
+	auth := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(auth, prefix) {
+		return types.Token{}, &types.AuthzError{
+			Code:   "invalid_request",
+			Status: http.StatusUnauthorized,
+			Realm:  cfg.realm,
+		}
+	}
+
+	value := strings.TrimPrefix(auth, prefix)
+	token, err := cfg.provider.LookupToken(value)
+	if err != nil || token.IsRevoked || token.IsExpired {
+		return types.Token{}, &types.AuthzError{
+			Code:        "invalid_token",
+			Description: "The access token is invalid, expired or has been revoked.",
+			Status:      http.StatusUnauthorized,
+			Realm:       cfg.realm,
+		}
+	}
+
+	granted := make(map[string]bool, len(token.Scope))
+	for _, scope := range token.Scope {
+		granted[scope.ID] = true
+	}
+
+	for _, scope := range requiredScopes {
+		if !granted[scope] {
+			return types.Token{}, &types.AuthzError{
+				Code:        "insufficient_scope",
+				Description: "The access token does not grant the required scope(s).",
+				Status:      http.StatusForbidden,
+				Realm:       cfg.realm,
+			}
+		}
+	}
+
+	return token, nil
+}
+
+// challenge writes authzErr as a WWW-Authenticate header and RFC 6750 JSON
+// body, with the status code recorded on authzErr.
+func challenge(w http.ResponseWriter, authzErr *types.AuthzError) {
+	w.Header().Set("WWW-Authenticate", authzErr.Error())
+
+	status := authzErr.Status
+	if status == 0 {
+		status = http.StatusBadRequest
+	}
+
+	writeJSON(w, status, authzErr)
+}
@@ -0,0 +1,118 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hooklift/oauth2/providers/test"
+	"github.com/hooklift/oauth2/types"
+)
+
+func protectedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func resourceRequest(bearer string) *http.Request {
+	req, _ := http.NewRequest("GET", "https://example.com/api/things", nil)
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	return req
+}
+
+// TestResourceProtectMissingToken tests that a request with no
+// Authorization header is rejected with a 401 invalid_request challenge,
+// per http://tools.ietf.org/html/rfc6750#section-3.1
+func TestResourceProtectMissingToken(t *testing.T) {
+	cfg := setupTest()
+
+	w := httptest.NewRecorder()
+	ResourceProtect(protectedHandler(), cfg).ServeHTTP(w, resourceRequest(""))
+
+	equals(t, http.StatusUnauthorized, w.Code)
+	assert(t, strings.Contains(w.Header().Get("WWW-Authenticate"), `error="invalid_request"`), "got: %s", w.Header().Get("WWW-Authenticate"))
+}
+
+// TestResourceProtectUnknownToken tests that a bearer token the provider
+// doesn't recognize is rejected as invalid_token with a 401.
+func TestResourceProtectUnknownToken(t *testing.T) {
+	cfg := setupTest()
+
+	w := httptest.NewRecorder()
+	ResourceProtect(protectedHandler(), cfg).ServeHTTP(w, resourceRequest("does-not-exist"))
+
+	equals(t, http.StatusUnauthorized, w.Code)
+	assert(t, strings.Contains(w.Header().Get("WWW-Authenticate"), `error="invalid_token"`), "got: %s", w.Header().Get("WWW-Authenticate"))
+}
+
+// TestResourceProtectExpiredToken tests that an expired token is rejected
+// as invalid_token with a 401.
+func TestResourceProtectExpiredToken(t *testing.T) {
+	cfg := setupTest()
+	provider := cfg.provider.(*test.Provider)
+	provider.SetToken(types.Token{Value: "expired-token", IsExpired: true})
+
+	w := httptest.NewRecorder()
+	ResourceProtect(protectedHandler(), cfg).ServeHTTP(w, resourceRequest("expired-token"))
+
+	equals(t, http.StatusUnauthorized, w.Code)
+	assert(t, strings.Contains(w.Header().Get("WWW-Authenticate"), `error="invalid_token"`), "got: %s", w.Header().Get("WWW-Authenticate"))
+}
+
+// TestResourceProtectRevokedToken tests that a revoked token is rejected as
+// invalid_token with a 401.
+func TestResourceProtectRevokedToken(t *testing.T) {
+	cfg := setupTest()
+	provider := cfg.provider.(*test.Provider)
+	provider.SetToken(types.Token{Value: "revoked-token", IsRevoked: true})
+
+	w := httptest.NewRecorder()
+	ResourceProtect(protectedHandler(), cfg).ServeHTTP(w, resourceRequest("revoked-token"))
+
+	equals(t, http.StatusUnauthorized, w.Code)
+	assert(t, strings.Contains(w.Header().Get("WWW-Authenticate"), `error="invalid_token"`), "got: %s", w.Header().Get("WWW-Authenticate"))
+}
+
+// TestResourceProtectInsufficientScope tests that a valid token lacking a
+// required scope is rejected as insufficient_scope with a 403, per
+// http://tools.ietf.org/html/rfc6750#section-3.1
+func TestResourceProtectInsufficientScope(t *testing.T) {
+	cfg := setupTest()
+	provider := cfg.provider.(*test.Provider)
+	provider.SetToken(types.Token{Value: "narrow-token", Scope: []types.Scope{{ID: "identity"}}})
+
+	w := httptest.NewRecorder()
+	ResourceProtect(protectedHandler(), cfg, "admin").ServeHTTP(w, resourceRequest("narrow-token"))
+
+	equals(t, http.StatusForbidden, w.Code)
+	assert(t, strings.Contains(w.Header().Get("WWW-Authenticate"), `error="insufficient_scope"`), "got: %s", w.Header().Get("WWW-Authenticate"))
+}
+
+// TestResourceProtectValidToken tests the happy path: a valid token
+// granting the required scope reaches next, and TokenFromContext exposes
+// it.
+func TestResourceProtectValidToken(t *testing.T) {
+	cfg := setupTest()
+	provider := cfg.provider.(*test.Provider)
+	provider.SetToken(types.Token{Value: "good-token", Scope: []types.Scope{{ID: "identity"}}})
+
+	var gotToken types.Token
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotToken, _ = TokenFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	ResourceProtect(next, cfg, "identity").ServeHTTP(w, resourceRequest("good-token"))
+
+	equals(t, http.StatusOK, w.Code)
+	equals(t, "good-token", gotToken.Value)
+}
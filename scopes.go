@@ -0,0 +1,62 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import (
+	"strings"
+
+	"github.com/hooklift/oauth2/types"
+)
+
+// NarrowRefreshScope validates the scopes requested when refreshing an
+// access token against the scopes originally granted, as required by
+// http://tools.ietf.org/html/rfc6749#section-6.
+//
+// When requested is empty, the original scopes are returned verbatim.
+// Otherwise every requested scope must already be present in original;
+// if any is not, an invalid_scope error listing the offending scopes is
+// returned.
+func NarrowRefreshScope(requested, original []types.Scope) ([]types.Scope, *types.AuthzError) {
+	if len(requested) == 0 {
+		return original, nil
+	}
+
+	allowed := make(map[string]bool, len(original))
+	for _, scope := range original {
+		allowed[scope.ID] = true
+	}
+
+	var unauthorized []string
+	for _, scope := range requested {
+		if !allowed[scope.ID] {
+			unauthorized = append(unauthorized, scope.ID)
+		}
+	}
+
+	if len(unauthorized) > 0 {
+		return nil, &types.AuthzError{
+			Code:        "invalid_scope",
+			Description: `Requested scopes contain unauthorized scope(s): "` + strings.Join(unauthorized, ", ") + `"`,
+		}
+	}
+
+	return requested, nil
+}
+
+// parseScopes splits a space-delimited scope parameter, as sent on a token
+// request, into the []types.Scope form the rest of this package works
+// with. An empty string yields no scopes.
+func parseScopes(scope string) []types.Scope {
+	if scope == "" {
+		return nil
+	}
+
+	ids := strings.Fields(scope)
+	scopes := make([]types.Scope, len(ids))
+	for i, id := range ids {
+		scopes[i] = types.Scope{ID: id}
+	}
+	return scopes
+}
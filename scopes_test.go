@@ -0,0 +1,63 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import (
+	"testing"
+
+	"github.com/hooklift/oauth2/types"
+)
+
+// TestNarrowRefreshScope tests that scopes requested on a refresh_token grant
+// are only ever narrowed to a subset of those originally granted, per
+// http://tools.ietf.org/html/rfc6749#section-6.
+func TestNarrowRefreshScope(t *testing.T) {
+	original := []types.Scope{
+		{ID: "identity"},
+		{ID: "offline_access"},
+	}
+
+	tests := []struct {
+		name      string
+		requested []types.Scope
+		wantErr   bool
+	}{
+		{
+			name:      "identical scopes",
+			requested: []types.Scope{{ID: "identity"}, {ID: "offline_access"}},
+		},
+		{
+			name:      "proper subset",
+			requested: []types.Scope{{ID: "identity"}},
+		},
+		{
+			name:      "empty request reuses original scopes",
+			requested: nil,
+		},
+		{
+			name:      "superset must fail",
+			requested: []types.Scope{{ID: "identity"}, {ID: "admin"}},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		scopes, err := NarrowRefreshScope(tt.requested, original)
+
+		if tt.wantErr {
+			assert(t, err != nil, "%s: expected an invalid_scope error", tt.name)
+			equals(t, "invalid_scope", err.Code)
+			continue
+		}
+
+		assert(t, err == nil, "%s: unexpected error: %v", tt.name, err)
+
+		if len(tt.requested) == 0 {
+			equals(t, len(original), len(scopes))
+		} else {
+			equals(t, len(tt.requested), len(scopes))
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import "github.com/hooklift/oauth2/types"
+
+// TokenExchangeGrantType is the grant_type value that selects the RFC 8693
+// token exchange flow. See
+// http://tools.ietf.org/html/rfc8693#section-2.1
+const TokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+// AccessTokenType identifies an OAuth 2.0 access token as a subject or
+// requested token type, per
+// http://tools.ietf.org/html/rfc8693#section-3
+const AccessTokenType = "urn:ietf:params:oauth:token-type:access_token"
+
+// tokenExchangeResponse extends the standard token response with the
+// issued_token_type parameter required by
+// http://tools.ietf.org/html/rfc8693#section-2.2.1
+type tokenExchangeResponse struct {
+	types.Token
+	IssuedTokenType string `json:"issued_token_type"`
+}
+
+// NarrowExchangeAudience validates the audience requested in a token
+// exchange against what the client is permitted to exchange for, using
+// canExchange, the caller-supplied http://tools.ietf.org/html/rfc8693
+// CanExchange(clientID, audience) check. The exchanged token is never
+// broader than what was requested: every requested audience must be
+// permitted, and if any is not, an invalid_target error is returned instead
+// of a narrower audience. If requested is empty, no audience restriction is
+// applied.
+func NarrowExchangeAudience(requested []string, canExchange func(audience string) bool) ([]string, *types.AuthzError) {
+	if len(requested) == 0 {
+		return nil, nil
+	}
+
+	audience := make([]string, 0, len(requested))
+	for _, aud := range requested {
+		if !canExchange(aud) {
+			return nil, &types.AuthzError{
+				Code:        "invalid_target",
+				Description: "Client is not permitted to exchange a token for audience: " + aud,
+			}
+		}
+		audience = append(audience, aud)
+	}
+
+	return audience, nil
+}
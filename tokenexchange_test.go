@@ -0,0 +1,62 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/hooklift/oauth2/providers/test"
+	"github.com/hooklift/oauth2/types"
+)
+
+// TestTokenExchange tests the happy path for
+// http://tools.ietf.org/html/rfc8693, minting an audience-restricted token
+// from a subject token.
+func TestTokenExchange(t *testing.T) {
+	cfg := setupTest()
+	provider := cfg.provider.(*test.Provider)
+
+	subjectToken, err := provider.GenToken(types.Grant{
+		ClientID: "testclient",
+		Scopes:   types.Scopes{{ID: "identity"}},
+	}, types.Client{ID: "testclient"}, false, cfg.tokenExpiration, nil)
+	ok(t, err)
+
+	queryStr := url.Values{
+		"grant_type":         {TokenExchangeGrantType},
+		"subject_token":      {subjectToken.Value},
+		"subject_token_type": {AccessTokenType},
+		"audience":           {"https://downstream.example.com"},
+	}
+
+	buffer := bytes.NewBufferString(queryStr.Encode())
+	req, err := http.NewRequest("POST", "https://example.com/oauth2/tokens", buffer)
+	ok(t, err)
+	req.Header.Set("Content-type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("testclient", "testclient")
+
+	w := httptest.NewRecorder()
+	IssueToken(w, req, cfg)
+	equals(t, http.StatusOK, w.Code)
+
+	resp := tokenExchangeResponse{}
+	ok(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	equals(t, AccessTokenType, resp.IssuedTokenType)
+	assert(t, resp.Value != "", "expected an access token to be issued")
+	equals(t, []string{"https://downstream.example.com"}, resp.Audience)
+
+	// The audience restriction must be persisted on the provider side, not
+	// just returned in this one response, so a later Introspect or
+	// ResourceProtect call against the same token value can recover it.
+	persisted, err := provider.LookupToken(resp.Value)
+	ok(t, err)
+	equals(t, []string{"https://downstream.example.com"}, persisted.Audience)
+}
@@ -0,0 +1,410 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package oauth2
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hooklift/oauth2/types"
+)
+
+// noStoreHeaders marks a response as one that must never be cached, as
+// required by http://tools.ietf.org/html/rfc6749#section-5.1 for every
+// response carrying a token or a token error.
+func noStoreHeaders(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+}
+
+// writeAuthzError writes authzErr as the token endpoint's JSON error body,
+// per http://tools.ietf.org/html/rfc6749#section-5.2
+func writeAuthzError(w http.ResponseWriter, status int, authzErr *types.AuthzError) {
+	writeJSON(w, status, authzErr)
+}
+
+// requireClientAuth authenticates the client making a request using HTTP
+// Basic credentials, per http://tools.ietf.org/html/rfc6749#section-2.3.1.
+// Unlike resolveClient, it never allows an unauthenticated client through,
+// which is what RevokeToken and Introspect need: both always act on behalf
+// of whichever client is making the call.
+func requireClientAuth(r *http.Request, cfg *config) (types.Client, *types.AuthzError) {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		return types.Client{}, &types.AuthzError{
+			Code:        "unauthorized_client",
+			Description: "Client authentication is required.",
+		}
+	}
+
+	client, err := cfg.provider.LookupClient(id)
+	if err != nil || client.Secret == "" || client.Secret != secret {
+		return types.Client{}, &types.AuthzError{
+			Code:        "unauthorized_client",
+			Description: "Client authentication failed.",
+		}
+	}
+
+	return client, nil
+}
+
+// resolveClient identifies the client making a token endpoint request,
+// either through HTTP Basic credentials or, for public clients that have
+// none, through the client_id form parameter. authenticated reports
+// whether the client actually proved its identity, which AuthenticateClient
+// uses to decide whether a code_verifier is also required.
+func resolveClient(r *http.Request, cfg *config) (client types.Client, authenticated bool, authzErr *types.AuthzError) {
+	if id, secret, ok := r.BasicAuth(); ok {
+		client, err := cfg.provider.LookupClient(id)
+		if err != nil || client.Secret == "" || client.Secret != secret {
+			return types.Client{}, false, &types.AuthzError{
+				Code:        "unauthorized_client",
+				Description: "Client authentication failed.",
+			}
+		}
+		return client, true, nil
+	}
+
+	id := r.FormValue("client_id")
+	if id == "" {
+		return types.Client{}, false, &types.AuthzError{
+			Code:        "unauthorized_client",
+			Description: "Client authentication is required.",
+		}
+	}
+
+	client, err := cfg.provider.LookupClient(id)
+	if err != nil {
+		return types.Client{}, false, &types.AuthzError{
+			Code:        "unauthorized_client",
+			Description: "Client authentication failed.",
+		}
+	}
+
+	return client, false, nil
+}
+
+// IssueToken implements the OAuth 2.0 token endpoint, dispatching on
+// grant_type to the authorization_code, password, client_credentials, and
+// refresh_token grants defined by http://tools.ietf.org/html/rfc6749#section-4
+// and to the token exchange grant defined by http://tools.ietf.org/html/rfc8693
+func IssueToken(w http.ResponseWriter, r *http.Request, cfg *config) {
+	noStoreHeaders(w)
+
+	if err := r.ParseForm(); err != nil {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "invalid_request",
+			Description: "Unable to parse request: " + err.Error(),
+		})
+		return
+	}
+
+	grantType := r.FormValue("grant_type")
+
+	client, authenticated, authzErr := resolveClient(r, cfg)
+	if authzErr != nil {
+		writeAuthzError(w, http.StatusBadRequest, authzErr)
+		return
+	}
+
+	hasCodeVerifier := r.FormValue("code_verifier") != ""
+	if authzErr := AuthenticateClient(client, grantType, authenticated, hasCodeVerifier); authzErr != nil {
+		writeAuthzError(w, http.StatusBadRequest, authzErr)
+		return
+	}
+
+	switch grantType {
+	case "authorization_code":
+		issueAuthzCodeToken(w, r, cfg, client)
+	case "password":
+		issuePasswordToken(w, r, cfg, client)
+	case "client_credentials":
+		issueClientCredentialsToken(w, r, cfg, client)
+	case "refresh_token":
+		issueRefreshToken(w, r, cfg, client)
+	case TokenExchangeGrantType:
+		issueTokenExchange(w, r, cfg, client)
+	default:
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "unsupported_grant_type",
+			Description: "Grant type not supported: " + grantType,
+		})
+	}
+}
+
+// issueAuthzCodeToken implements http://tools.ietf.org/html/rfc6749#section-4.1.3,
+// plus PKCE verification per http://tools.ietf.org/html/rfc7636#section-4.6
+func issueAuthzCodeToken(w http.ResponseWriter, r *http.Request, cfg *config, client types.Client) {
+	code := r.FormValue("code")
+	grantCode, err := cfg.provider.LookupGrantCode(code)
+	if err != nil || grantCode.IsRevoked || grantCode.IsExpired || grantCode.IsUsed {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "invalid_grant",
+			Description: "Grant code is invalid, expired or has already been used.",
+		})
+		return
+	}
+
+	if grantCode.ClientID != client.ID {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "invalid_grant",
+			Description: "Grant code was generated for a different client.",
+		})
+		return
+	}
+
+	if redirectURI := r.FormValue("redirect_uri"); redirectURI != "" && grantCode.RedirectURL != nil && redirectURI != grantCode.RedirectURL.String() {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "invalid_grant",
+			Description: "Grant code was generated for a different redirect URI.",
+		})
+		return
+	}
+
+	if grantCode.CodeChallenge == "" && client.Type == types.Public && cfg.requirePKCEForPublicClients {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "invalid_request",
+			Description: "This server requires public clients to use PKCE.",
+		})
+		return
+	}
+
+	if grantCode.CodeChallenge != "" {
+		codeVerifier := r.FormValue("code_verifier")
+		if codeVerifier == "" {
+			writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+				Code:        "invalid_request",
+				Description: "code_verifier is required for this authorization code.",
+			})
+			return
+		}
+
+		if authzErr := VerifyPKCE(codeVerifier, grantCode.CodeChallenge, grantCode.CodeChallengeMethod, cfg.s256Only); authzErr != nil {
+			writeAuthzError(w, http.StatusBadRequest, authzErr)
+			return
+		}
+	}
+
+	if err := cfg.provider.UseGrantCode(code); err != nil {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "invalid_grant",
+			Description: "Unable to redeem grant code: " + err.Error(),
+		})
+		return
+	}
+
+	grant := types.Grant{ClientID: client.ID, Scopes: types.Scopes(grantCode.Scope)}
+	token, err := cfg.provider.GenToken(grant, client, true, cfg.tokenExpiration, nil)
+	if err != nil {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "server_error",
+			Description: "Unable to generate access token: " + err.Error(),
+		})
+		return
+	}
+
+	if hasScope(grantCode.Scope, OpenIDScope) {
+		idToken, err := mintIDToken(cfg, client, token, grantCode.Nonce)
+		if err != nil {
+			writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+				Code:        "server_error",
+				Description: "Unable to mint id_token: " + err.Error(),
+			})
+			return
+		}
+		token.IDToken = idToken
+	}
+
+	writeJSON(w, http.StatusOK, token)
+}
+
+// mintIDToken builds the id_token minted alongside token when the client
+// requested the openid scope, per
+// http://openid.net/specs/openid-connect-core-1_0.html#IDTokenClass. It
+// enriches the standard claims with whatever the provider's UserInfo hook
+// returns about the subject, per
+// http://openid.net/specs/openid-connect-core-1_0.html#UserInfo
+func mintIDToken(cfg *config, client types.Client, token types.Token, nonce string) (string, error) {
+	extra, err := cfg.provider.UserInfo(client.ID)
+	if err != nil {
+		return "", err
+	}
+
+	return NewIDToken(cfg.signingKey, cfg.signingKeyID, IDTokenClaims{
+		Issuer:   cfg.issuer,
+		Subject:  client.ID,
+		Audience: client.ID,
+		Expiry:   token.ExpiresAt,
+		IssuedAt: token.IssuedAt,
+		AuthTime: token.IssuedAt,
+		Nonce:    nonce,
+		Extra:    extra,
+	})
+}
+
+// hasScope reports whether scopes contains one with the given id.
+func hasScope(scopes []types.Scope, id string) bool {
+	for _, scope := range scopes {
+		if scope.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// issuePasswordToken implements http://tools.ietf.org/html/rfc6749#section-4.3
+func issuePasswordToken(w http.ResponseWriter, r *http.Request, cfg *config, client types.Client) {
+	grant, err := cfg.provider.Authenticate(r.FormValue("username"), r.FormValue("password"))
+	if err != nil {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "invalid_grant",
+			Description: "Resource owner credentials are invalid.",
+		})
+		return
+	}
+	grant.ClientID = client.ID
+
+	token, err := cfg.provider.GenToken(grant, client, true, cfg.tokenExpiration, nil)
+	if err != nil {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "server_error",
+			Description: "Unable to generate access token: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, token)
+}
+
+// issueClientCredentialsToken implements http://tools.ietf.org/html/rfc6749#section-4.4
+func issueClientCredentialsToken(w http.ResponseWriter, r *http.Request, cfg *config, client types.Client) {
+	grant, err := cfg.provider.ClientGrant(client)
+	if err != nil {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "invalid_grant",
+			Description: "Client is not granted any scopes.",
+		})
+		return
+	}
+
+	// A refresh token SHOULD NOT be included, per
+	// http://tools.ietf.org/html/rfc6749#section-4.4.3
+	token, err := cfg.provider.GenToken(grant, client, false, cfg.tokenExpiration, nil)
+	if err != nil {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "server_error",
+			Description: "Unable to generate access token: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, token)
+}
+
+// issueRefreshToken implements http://tools.ietf.org/html/rfc6749#section-6
+func issueRefreshToken(w http.ResponseWriter, r *http.Request, cfg *config, client types.Client) {
+	original, err := cfg.provider.LookupToken(r.FormValue("refresh_token"))
+	if err != nil || original.IsRevoked || original.IsExpired {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "invalid_grant",
+			Description: "Refresh token is invalid, expired or has been revoked.",
+		})
+		return
+	}
+
+	narrowed, authzErr := NarrowRefreshScope(parseScopes(r.FormValue("scope")), original.Scope)
+	if authzErr != nil {
+		writeAuthzError(w, http.StatusBadRequest, authzErr)
+		return
+	}
+
+	grant := types.Grant{ClientID: client.ID, Scopes: types.Scopes(narrowed)}
+	token, err := cfg.provider.GenToken(grant, client, true, cfg.tokenExpiration, nil)
+	if err != nil {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "server_error",
+			Description: "Unable to generate access token: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, token)
+}
+
+// issueTokenExchange implements http://tools.ietf.org/html/rfc8693, minting
+// a new access token scoped to the requested (and permitted) audience from
+// an existing subject token.
+func issueTokenExchange(w http.ResponseWriter, r *http.Request, cfg *config, client types.Client) {
+	subjectToken, err := cfg.provider.LookupToken(r.FormValue("subject_token"))
+	if err != nil || subjectToken.IsRevoked || subjectToken.IsExpired {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "invalid_grant",
+			Description: "subject_token is invalid, expired or has been revoked.",
+		})
+		return
+	}
+
+	audience, authzErr := NarrowExchangeAudience(r.Form["audience"], func(aud string) bool {
+		return cfg.provider.CanExchange(client.ID, aud)
+	})
+	if authzErr != nil {
+		writeAuthzError(w, http.StatusBadRequest, authzErr)
+		return
+	}
+
+	scope, authzErr := NarrowRefreshScope(parseScopes(r.FormValue("scope")), subjectToken.Scope)
+	if authzErr != nil {
+		writeAuthzError(w, http.StatusBadRequest, authzErr)
+		return
+	}
+
+	grant := types.Grant{ClientID: client.ID, Scopes: types.Scopes(scope)}
+	token, err := cfg.provider.GenToken(grant, client, false, cfg.tokenExpiration, audience)
+	if err != nil {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "server_error",
+			Description: "Unable to generate access token: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tokenExchangeResponse{
+		Token:           token,
+		IssuedTokenType: AccessTokenType,
+	})
+}
+
+// RevokeToken implements http://tools.ietf.org/html/rfc7009, revoking the
+// access or refresh token named by the last path segment of r.URL.
+func RevokeToken(w http.ResponseWriter, r *http.Request, cfg *config) {
+	noStoreHeaders(w)
+
+	client, authzErr := requireClientAuth(r, cfg)
+	if authzErr != nil {
+		writeAuthzError(w, http.StatusBadRequest, authzErr)
+		return
+	}
+
+	value := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	token, err := cfg.provider.LookupToken(value)
+	if err == nil && token.ClientID != client.ID {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "invalid_grant",
+			Description: "Token was issued to a different client.",
+		})
+		return
+	}
+
+	if err := cfg.provider.RevokeToken(value); err != nil {
+		writeAuthzError(w, http.StatusBadRequest, &types.AuthzError{
+			Code:        "invalid_request",
+			Description: "Unable to revoke token: " + err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
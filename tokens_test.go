@@ -11,6 +11,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/hooklift/oauth2/providers/test"
 	"github.com/hooklift/oauth2/types"
@@ -166,7 +167,7 @@ func TestRefreshToken(t *testing.T) {
 	}
 	accessToken, err := provider.GenToken(noAuthzGrant, types.Client{
 		ID: "test_client_id",
-	}, true, cfg.tokenExpiration)
+	}, true, cfg.tokenExpiration, nil)
 	ok(t, err)
 
 	queryStr := url.Values{
@@ -219,7 +220,44 @@ func TestAuthzCodeOwnership(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &authzErr)
 	ok(t, err)
 	equals(t, "invalid_grant", authzErr.Code)
-	equals(t, "Grant code was generated for a different redirect URI.", authzErr.Description)
+	equals(t, "Grant code was generated for a different client.", authzErr.Description)
+}
+
+// TestRequirePKCEForPublicClients tests that WithPKCERequiredForPublicClients
+// rejects a public client's authorization_code exchange when no
+// code_challenge was ever recorded on the grant code.
+func TestRequirePKCEForPublicClients(t *testing.T) {
+	cfg := New(test.NewProvider(false), 600*time.Second, WithPKCERequiredForPublicClients(true))
+
+	provider := cfg.provider.(*test.Provider)
+	err := provider.AddGrantCode(types.GrantCode{
+		Value:       "no_pkce_code",
+		ClientID:    "test_public",
+		RedirectURL: mustParseURL("https://example.com/oauth2/callback"),
+		Scope:       []types.Scope{{ID: "identity"}},
+	})
+	ok(t, err)
+
+	req := AuthzGrantTokenRequestTest(t, "authorization_code", "no_pkce_code")
+	req.Form = url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {"no_pkce_code"},
+		"redirect_uri": {"https://example.com/oauth2/callback"},
+		"client_id":    {"test_public"},
+		// A code_verifier gets the request past AuthenticateClient, so it is
+		// the missing code_challenge that the option rejects.
+		"code_verifier": {"test-code-verifier"},
+	}
+	req.PostForm = req.Form
+
+	w := httptest.NewRecorder()
+	IssueToken(w, req, cfg)
+
+	equals(t, http.StatusBadRequest, w.Code)
+
+	authzErr := types.AuthzError{}
+	ok(t, json.Unmarshal(w.Body.Bytes(), &authzErr))
+	equals(t, "invalid_request", authzErr.Code)
 }
 
 // TestRevokeToken tests happy path for revoking refresh and access tokens.
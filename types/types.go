@@ -6,6 +6,7 @@
 package types
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"time"
@@ -28,8 +29,28 @@ type Client struct {
 	HomepageURL *url.URL
 	// Redirect URL registered for this client.
 	RedirectURL *url.URL
+	// Client's type, either Confidential or Public, as defined by
+	// http://tools.ietf.org/html/rfc6749#section-2.1
+	Type ClientType
+	// Client's secret. Only set for confidential clients.
+	Secret string
 }
 
+// ClientType identifies whether a client can keep its credentials
+// confidential, per http://tools.ietf.org/html/rfc6749#section-2.1 and
+// http://tools.ietf.org/html/rfc8252#section-8.4.
+type ClientType string
+
+const (
+	// Confidential clients can maintain the confidentiality of their
+	// credentials, e.g.: server-side web applications.
+	Confidential ClientType = "confidential"
+	// Public clients cannot, e.g.: native and single-page applications.
+	// They have no client secret, and must present a PKCE code_verifier
+	// instead when exchanging an authorization code.
+	Public ClientType = "public"
+)
+
 // Scope defines a type for manipulating OAuth2 scopes.
 type Scope struct {
 	// Scope's identifier. Example: read
@@ -38,6 +59,20 @@ type Scope struct {
 	Description string
 }
 
+// Scopes is a set of authorization scopes granted together.
+type Scopes []Scope
+
+// Grant represents the scopes a client or resource owner has been granted,
+// independently of how they were obtained (authorization code, resource
+// owner credentials, client credentials, or a refresh token), used as
+// input to a provider's token-minting logic.
+type Grant struct {
+	// Client this grant was issued to.
+	ClientID string
+	// Scopes the client was granted.
+	Scopes Scopes
+}
+
 // GrantCode represents an authorization grant code.
 type GrantCode struct {
 	// Authorization code value.
@@ -56,6 +91,16 @@ type GrantCode struct {
 	IsExpired bool
 	// Whether or not this code was already used.
 	IsUsed bool
+	// Code challenge sent by the client in the authorization request, used to
+	// implement PKCE (http://tools.ietf.org/html/rfc7636).
+	CodeChallenge string
+	// Method used to derive CodeChallenge from the client's code verifier.
+	// Either "plain" or "S256".
+	CodeChallengeMethod string
+	// Nonce sent by the client in the authorization request when requesting
+	// the openid scope, echoed back in the id_token to prevent replay
+	// attacks. See http://openid.net/specs/openid-connect-core-1_0.html#AuthRequest
+	Nonce string
 }
 
 // Token represents an access token.
@@ -70,8 +115,21 @@ type Token struct {
 	ExpiresIn string `json:"expires_in"`
 	// Refresh token optionally emitted along with access token
 	RefreshToken string `json:"refresh_token,omitempty"`
+	// ID token minted when the openid scope was requested. See
+	// http://openid.net/specs/openid-connect-core-1_0.html#IDToken
+	IDToken string `json:"id_token,omitempty"`
+	// Audience this token is restricted to, set when it was minted through
+	// token exchange (http://tools.ietf.org/html/rfc8693).
+	Audience []string `json:"aud,omitempty"`
 	// Authorization scoped allowed for this token
 	Scope []Scope `json:"-"`
+	// IssuedAt is when this token was minted, as a Unix timestamp. Used to
+	// answer the introspection endpoint's "iat" claim
+	// (http://tools.ietf.org/html/rfc7662#section-2.2).
+	IssuedAt int64 `json:"-"`
+	// ExpiresAt is when this token stops being valid, as a Unix timestamp.
+	// Used to answer the introspection endpoint's "exp" claim.
+	ExpiresAt int64 `json:"-"`
 	// Whether or not this token was revoked.
 	IsRevoked bool `json:"-"`
 	// Whether or not this token was expired.
@@ -83,10 +141,26 @@ type AuthzError struct {
 	Description string `json:"error_description"`
 	URI         string `json:"error_uri,omitempty"`
 	State       string `json:"state,omitempty"`
+	// HTTP status code this error must be reported with. Defaults to 400
+	// when left unset, except where RFC 6750 requires otherwise (401 for a
+	// missing or invalid bearer token, 403 for insufficient_scope).
+	Status int `json:"-"`
+	// Realm identifying the protected resource, sent as part of the
+	// WWW-Authenticate challenge. See
+	// http://tools.ietf.org/html/rfc6750#section-3
+	Realm string `json:"-"`
 }
 
+// Error composes the WWW-Authenticate challenge form of this error, as
+// required by http://tools.ietf.org/html/rfc6750#section-3 for bearer
+// token errors reported on protected resources.
 func (a *AuthzError) Error() string {
-	str := fmt.Sprintf(`error="%s"`, a.Code)
+	str := "Bearer"
+	if a.Realm != "" {
+		str += fmt.Sprintf(` realm="%s"`, a.Realm)
+	}
+
+	str += fmt.Sprintf(`,error="%s"`, a.Code)
 	if a.Description != "" {
 		str += fmt.Sprintf(`,error_description="%s"`, a.Description)
 	}
@@ -96,3 +170,9 @@ func (a *AuthzError) Error() string {
 	}
 	return str
 }
+
+// JSON composes the JSON body form of this error, as returned by the token
+// endpoint per http://tools.ietf.org/html/rfc6749#section-5.2
+func (a *AuthzError) JSON() ([]byte, error) {
+	return json.Marshal(a)
+}
@@ -0,0 +1,63 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, version 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestAuthzErrorJSON tests that JSON composes the token-endpoint error body
+// form, per http://tools.ietf.org/html/rfc6749#section-5.2, distinct from
+// the WWW-Authenticate challenge form Error composes.
+func TestAuthzErrorJSON(t *testing.T) {
+	authzErr := &AuthzError{
+		Code:        "invalid_grant",
+		Description: "Grant code is invalid, expired or has already been used.",
+		Status:      400,
+		Realm:       "oauth2",
+	}
+
+	body, err := authzErr.JSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := map[string]string{}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got["error"] != authzErr.Code {
+		t.Fatalf("exp error: %s, got: %s", authzErr.Code, got["error"])
+	}
+	if got["error_description"] != authzErr.Description {
+		t.Fatalf("exp error_description: %s, got: %s", authzErr.Description, got["error_description"])
+	}
+
+	// Status and Realm are only used to compose the WWW-Authenticate
+	// challenge form; they must not leak into the JSON body.
+	if _, ok := got["status"]; ok {
+		t.Fatalf("did not expect a status field in the JSON body")
+	}
+	if _, ok := got["realm"]; ok {
+		t.Fatalf("did not expect a realm field in the JSON body")
+	}
+}
+
+// TestAuthzErrorError tests that Error composes the WWW-Authenticate
+// challenge form, per http://tools.ietf.org/html/rfc6750#section-3.
+func TestAuthzErrorError(t *testing.T) {
+	authzErr := &AuthzError{
+		Code:        "invalid_token",
+		Description: "The access token is invalid, expired or has been revoked.",
+		Realm:       "oauth2",
+	}
+
+	want := `Bearer realm="oauth2",error="invalid_token",error_description="The access token is invalid, expired or has been revoked."`
+	if got := authzErr.Error(); got != want {
+		t.Fatalf("exp: %s\ngot: %s", want, got)
+	}
+}